@@ -0,0 +1,38 @@
+package service
+
+import (
+	"fmt"
+	"os"
+)
+
+type consoleLogger struct{}
+
+// ConsoleLogger logs to os.Stdout/os.Stderr. It is returned by Logger when
+// the service is running interactively.
+var ConsoleLogger = consoleLogger{}
+
+func (consoleLogger) Error(v ...interface{}) error {
+	_, err := fmt.Fprintln(os.Stderr, v...)
+	return err
+}
+func (consoleLogger) Warning(v ...interface{}) error {
+	_, err := fmt.Fprintln(os.Stdout, v...)
+	return err
+}
+func (consoleLogger) Info(v ...interface{}) error {
+	_, err := fmt.Fprintln(os.Stdout, v...)
+	return err
+}
+
+func (consoleLogger) Errorf(format string, a ...interface{}) error {
+	_, err := fmt.Fprintf(os.Stderr, format+"\n", a...)
+	return err
+}
+func (consoleLogger) Warningf(format string, a ...interface{}) error {
+	_, err := fmt.Fprintf(os.Stdout, format+"\n", a...)
+	return err
+}
+func (consoleLogger) Infof(format string, a ...interface{}) error {
+	_, err := fmt.Fprintf(os.Stdout, format+"\n", a...)
+	return err
+}
@@ -0,0 +1,184 @@
+// Package service provides a simple way to install, remove, and run a Go
+// program as a system service across supported init systems.
+package service
+
+import (
+	"errors"
+	"time"
+)
+
+// Errors returned by the service package.
+var (
+	ErrNameFieldRequired    = errors.New("Config.Name field is required.")
+	ErrNoUserServiceSupport = errors.New("service: user-mode service installation is not supported by this init system")
+)
+
+// Interface is implemented by the caller's program to hook into service
+// lifecycle events.
+type Interface interface {
+	// Start is called after the service is installed and started by the
+	// system. It must not block; spawn a goroutine if the work is long
+	// running.
+	Start(s Service) error
+
+	// Stop is called when the service is requested to stop. It may block
+	// briefly to allow for a clean shutdown.
+	Stop(s Service) error
+}
+
+// Reloader may optionally be implemented by Interface to react to a
+// SIGHUP-style reload request without the service exiting.
+type Reloader interface {
+	Reload(s Service) error
+}
+
+// Shutdowner may optionally be implemented by Interface to run custom
+// graceful-shutdown logic in place of Stop. Run gives Shutdown up to
+// Config.StopTimeout to finish; Stop is called only if it does not finish
+// in time.
+type Shutdowner interface {
+	Shutdown(s Service) error
+}
+
+// KeyValue provides a list of custom key-value pairs, used for platform
+// specific options that don't warrant a dedicated Config field.
+type KeyValue map[string]interface{}
+
+func (kv KeyValue) string(name string, defaultValue string) string {
+	if v, found := kv[name]; found {
+		if value, is := v.(string); is {
+			return value
+		}
+	}
+	return defaultValue
+}
+
+func (kv KeyValue) int(name string, defaultValue int) int {
+	if v, found := kv[name]; found {
+		if value, is := v.(int); is {
+			return value
+		}
+	}
+	return defaultValue
+}
+
+func (kv KeyValue) bool(name string, defaultValue bool) bool {
+	if v, found := kv[name]; found {
+		if value, is := v.(bool); is {
+			return value
+		}
+	}
+	return defaultValue
+}
+
+// Config provides the setup for a Service. The Name field is required.
+type Config struct {
+	Name        string // Required name of the service. No spaces suggested.
+	DisplayName string // Display name, spaces allowed.
+	Description string // Description of the service.
+
+	UserName         string   // Run as username.
+	Arguments        []string // Run with arguments.
+	WorkingDirectory string   // Service working directory.
+	ChRoot           string
+
+	// UserService installs the service under the current (or Username)
+	// user's own service manager instead of the system-wide one, so no
+	// root privileges are required to run it. Not every init system
+	// supports this; backends that don't return ErrNoUserServiceSupport.
+	UserService bool
+	// Username identifies which user's service manager to target when
+	// UserService is set and Install is run as root on that user's
+	// behalf. Left empty, the user running Install is used.
+	Username string
+
+	// Option contains platform specific options. Each platform backend's
+	// templateData lists the keys it understands.
+	Option KeyValue
+
+	// StopTimeout bounds how long Run waits for an Interface implementing
+	// Shutdowner to finish before forcing Stop. Zero means a package
+	// default is used.
+	StopTimeout time.Duration
+
+	// RunWait, if set, is called by Run instead of blocking on the
+	// termination signal channel directly. Long-lived programs that
+	// already block on their own primitives (an HTTP server's Serve, a
+	// context, ...) can use this to avoid a redundant signal channel.
+	RunWait func()
+}
+
+// defaultStopTimeout is used in place of Config.StopTimeout when it is
+// left zero.
+const defaultStopTimeout = 10 * time.Second
+
+// Logger writes to the system log, falling back to the console when the
+// service is run interactively.
+type Logger interface {
+	Error(v ...interface{}) error
+	Warning(v ...interface{}) error
+	Info(v ...interface{}) error
+
+	Errorf(format string, a ...interface{}) error
+	Warningf(format string, a ...interface{}) error
+	Infof(format string, a ...interface{}) error
+}
+
+// Status represents the reported run state of an installed service.
+type Status byte
+
+const (
+	StatusUnknown Status = iota // The service state could not be determined.
+	StatusRunning
+	StatusStopped
+)
+
+// StatusInfo is the result of a Status check: the service's run state,
+// plus its process id when the backend was able to determine one. PID is
+// 0 when the service isn't running or the backend has no way to extract a
+// pid (not every init system exposes one).
+type StatusInfo struct {
+	Status Status
+	PID    int
+}
+
+// Service represents a service that can be run or controlled.
+type Service interface {
+	// Run should be called shortly after the program entry point. It blocks
+	// until the service is stopped, calling i.Start and i.Stop as needed.
+	Run() error
+
+	// Start signals to the OS service manager that this service should start.
+	Start() error
+	// Stop signals to the OS service manager that this service should stop.
+	Stop() error
+	// Restart signals to the OS service manager that this service should
+	// stop, then start.
+	Restart() error
+
+	// Install sets up the service to run on system startup.
+	Install() error
+	// Remove removes the service from system startup.
+	Remove() error
+
+	// Status reports whether the service is currently running, stopped, or
+	// in an undetermined state, along with its PID when available.
+	Status() (StatusInfo, error)
+
+	// Logger opens and returns a system logger. If the service is running
+	// interactively, it returns a ConsoleLogger instead.
+	Logger() (Logger, error)
+	// SystemLogger opens and returns a system logger, bypassing the
+	// interactive check Logger performs.
+	SystemLogger() (Logger, error)
+
+	String() string
+	// Interactive reports whether the service is running as an interactive
+	// session, rather than being started by the OS service manager.
+	Interactive() bool
+}
+
+// New creates a new service based on the underlying OS service manager.
+func New(i Interface, c *Config) (Service, error) {
+	return newService(i, c)
+}
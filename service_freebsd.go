@@ -0,0 +1,284 @@
+package service
+
+import (
+	"fmt"
+	"log/syslog"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/template"
+	"time"
+
+	"bitbucket.org/kardianos/osext"
+)
+
+type freebsdService struct {
+	i Interface
+	*Config
+
+	interactive bool
+}
+
+func newService(i Interface, c *Config) (Service, error) {
+	s := &freebsdService{
+		i:      i,
+		Config: c,
+	}
+	var err error
+	s.interactive, err = isInteractive()
+
+	return s, err
+}
+
+func isInteractive() (bool, error) {
+	return os.Getppid() != 1, nil
+}
+
+func (s *freebsdService) String() string {
+	if len(s.DisplayName) > 0 {
+		return s.DisplayName
+	}
+	return s.Name
+}
+
+func (s *freebsdService) Interactive() bool {
+	return s.interactive
+}
+
+func (s *freebsdService) configPath() string {
+	return "/usr/local/etc/rc.d/" + s.Name
+}
+
+// freebsdTemplateData mirrors linuxTemplateData for the subset of Config
+// options the rc.d template understands.
+type freebsdTemplateData struct {
+	Name        string
+	Display     string
+	Description string
+	Path        string
+
+	User        string
+	PIDFile     string
+	Environment []string
+}
+
+func (s *freebsdService) templateData(path string) *freebsdTemplateData {
+	opts := s.Option
+	var env []string
+	if v, ok := opts["Environment"]; ok {
+		env, _ = v.([]string)
+	}
+	return &freebsdTemplateData{
+		Name:        s.Name,
+		Display:     s.DisplayName,
+		Description: s.Description,
+		Path:        path,
+
+		User:        opts.string("User", s.UserName),
+		PIDFile:     opts.string("PIDFile", ""),
+		Environment: env,
+	}
+}
+
+func (s *freebsdService) Install() error {
+	if s.UserService {
+		return ErrNoUserServiceSupport
+	}
+	confPath := s.configPath()
+	if _, err := os.Stat(confPath); err == nil {
+		return fmt.Errorf("Init already exists: %s", confPath)
+	}
+
+	f, err := os.Create(confPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	path, err := osext.Executable()
+	if err != nil {
+		return err
+	}
+
+	if err = template.Must(template.New("rcdScript").Parse(rcdScript)).Execute(f, s.templateData(path)); err != nil {
+		return err
+	}
+
+	if err = os.Chmod(confPath, 0755); err != nil {
+		return err
+	}
+
+	return exec.Command("sysrc", s.Name+"_enable=YES").Run()
+}
+
+func (s *freebsdService) Remove() error {
+	exec.Command("sysrc", "-x", s.Name+"_enable").Run()
+	return os.Remove(s.configPath())
+}
+
+func (s *freebsdService) Logger() (Logger, error) {
+	if s.interactive {
+		return ConsoleLogger, nil
+	}
+	return s.SystemLogger()
+}
+
+func (s *freebsdService) SystemLogger() (Logger, error) {
+	return newSysLogger(s.Name)
+}
+
+// sysLogger writes to the local syslog daemon via /dev/log.
+type sysLogger struct {
+	*syslog.Writer
+}
+
+// newSysLogger opens a connection to the local syslog daemon, tagged with
+// name.
+func newSysLogger(name string) (Logger, error) {
+	w, err := syslog.New(syslog.LOG_INFO, name)
+	if err != nil {
+		return nil, err
+	}
+	return sysLogger{w}, nil
+}
+
+func (l sysLogger) Error(v ...interface{}) error {
+	return l.Writer.Err(fmt.Sprint(v...))
+}
+func (l sysLogger) Warning(v ...interface{}) error {
+	return l.Writer.Warning(fmt.Sprint(v...))
+}
+func (l sysLogger) Info(v ...interface{}) error {
+	return l.Writer.Info(fmt.Sprint(v...))
+}
+
+func (l sysLogger) Errorf(format string, a ...interface{}) error {
+	return l.Writer.Err(fmt.Sprintf(format, a...))
+}
+func (l sysLogger) Warningf(format string, a ...interface{}) error {
+	return l.Writer.Warning(fmt.Sprintf(format, a...))
+}
+func (l sysLogger) Infof(format string, a ...interface{}) error {
+	return l.Writer.Info(fmt.Sprintf(format, a...))
+}
+
+func (s *freebsdService) Run() (err error) {
+	err = s.i.Start(s)
+	if err != nil {
+		return err
+	}
+
+	sigChan := make(chan os.Signal, 3)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+
+	if s.RunWait != nil {
+		s.RunWait()
+	} else {
+		<-sigChan
+	}
+
+	return s.shutdown()
+}
+
+// shutdown gives an Interface implementing Shutdowner up to
+// Config.StopTimeout to run its own graceful-shutdown logic before falling
+// back to Stop.
+func (s *freebsdService) shutdown() error {
+	sd, ok := s.i.(Shutdowner)
+	if !ok {
+		return s.i.Stop(s)
+	}
+
+	timeout := s.StopTimeout
+	if timeout <= 0 {
+		timeout = defaultStopTimeout
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- sd.Shutdown(s) }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+		return s.i.Stop(s)
+	}
+}
+
+func (s *freebsdService) Start() error {
+	return exec.Command("service", s.Name, "start").Run()
+}
+
+func (s *freebsdService) Stop() error {
+	return exec.Command("service", s.Name, "stop").Run()
+}
+
+func (s *freebsdService) Restart() error {
+	if err := s.Stop(); err != nil {
+		return err
+	}
+	time.Sleep(50 * time.Millisecond)
+	return s.Start()
+}
+
+func (s *freebsdService) Status() (StatusInfo, error) {
+	err := exec.Command("service", s.Name, "status").Run()
+	if err == nil {
+		return StatusInfo{Status: StatusRunning, PID: pidFromFile(s.pidFile())}, nil
+	}
+	if _, ok := err.(*exec.ExitError); ok {
+		return StatusInfo{Status: StatusStopped}, nil
+	}
+	return StatusInfo{Status: StatusUnknown}, err
+}
+
+// pidFile returns the Option["PIDFile"] override if set, otherwise the
+// same default the rc.d template falls back to.
+func (s *freebsdService) pidFile() string {
+	return s.Option.string("PIDFile", "/var/run/"+s.Name+".pid")
+}
+
+// pidFromFile reads a pid that daemon(8) recorded at path. It returns 0
+// if the file is missing or doesn't hold a valid pid.
+func pidFromFile(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0
+	}
+	return pid
+}
+
+// rcdScript follows the standard FreeBSD rc.d boilerplate: source rc.subr,
+// declare name/rcvar/command, then hand off to load_rc_config. Go services
+// don't daemonize themselves, so command runs through daemon(8) (-r to
+// restart it if it exits, -P to have daemon write its own supervisor pid to
+// pidfile, so rc.subr's stop signals the supervisor) rather than invoking
+// {{.Path}} directly.
+const rcdScript = `#!/bin/sh
+#
+# PROVIDE: {{.Name}}
+# REQUIRE: LOGIN FILESYSTEMS
+# KEYWORD: shutdown
+
+. /etc/rc.subr
+
+name="{{.Name}}"
+desc="{{.Description}}"
+rcvar="{{.Name}}_enable"
+
+pidfile="{{if .PIDFile}}{{.PIDFile}}{{else}}/var/run/${name}.pid{{end}}"
+command="/usr/sbin/daemon"
+command_args="-P ${pidfile} -r {{.Path}}"
+{{if .User}}command_user="{{.User}}"
+{{end}}{{range .Environment}}export {{.}}
+{{end}}
+load_rc_config $name
+run_rc_command "$1"
+`
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func freebsdTemplateFixture() *freebsdService {
+	return &freebsdService{
+		Config: &Config{
+			Name:        "testsvc",
+			DisplayName: "Test Service",
+			Description: "a service for tests",
+			Option:      KeyValue{"PIDFile": "/var/run/testsvc.pid"},
+		},
+	}
+}
+
+// TestRcdScriptDaemonizesCommand is a regression test for rcdScript: Go
+// services don't self-daemonize, so command must run through daemon(8)
+// rather than execing the binary directly, or rc.subr blocks in the
+// foreground and never populates the pidfile.
+func TestRcdScriptDaemonizesCommand(t *testing.T) {
+	s := freebsdTemplateFixture()
+
+	var buf bytes.Buffer
+	err := template.Must(template.New("rcdScript").Parse(rcdScript)).Execute(&buf, s.templateData("/usr/bin/testsvc"))
+	if err != nil {
+		t.Fatalf("template: %v", err)
+	}
+	out := buf.String()
+
+	if !strings.Contains(out, `command="/usr/sbin/daemon"`) {
+		t.Errorf("rendered script does not run command through daemon(8):\n%s", out)
+	}
+	if !strings.Contains(out, "-P ${pidfile}") {
+		t.Errorf("rendered script does not tell daemon(8) to record its pid:\n%s", out)
+	}
+	if !strings.Contains(out, "/usr/bin/testsvc") {
+		t.Errorf("rendered script does not reference the service binary:\n%s", out)
+	}
+}
+
+func TestFreebsdPidFile(t *testing.T) {
+	s := freebsdTemplateFixture()
+	if got, want := s.pidFile(), "/var/run/testsvc.pid"; got != want {
+		t.Errorf("pidFile() = %q, want %q", got, want)
+	}
+
+	s.Option = KeyValue{}
+	if got, want := s.pidFile(), "/var/run/testsvc.pid"; got != want {
+		t.Errorf("pidFile() default = %q, want %q", got, want)
+	}
+}
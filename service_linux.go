@@ -2,29 +2,81 @@ package service
 
 import (
 	"fmt"
+	"log/syslog"
 	"os"
 	"os/exec"
 	"os/signal"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
 	"text/template"
 	"time"
 
 	"bitbucket.org/kardianos/osext"
 )
 
-const (
-	initSystemV = initFlavor(iota)
-	initUpstart
-	initSystemd
-)
+// InitSystem is implemented by each supported Linux init system backend.
+// Downstream users may implement their own and add it with
+// RegisterInitSystem, for example to support an init system not built into
+// this package.
+type InitSystem interface {
+	Install(s *linuxService) error
+	Remove(s *linuxService) error
+	Start(s *linuxService) error
+	Stop(s *linuxService) error
+	Status(s *linuxService) (StatusInfo, error)
+	ConfigPath(s *linuxService) string
+	Template() *template.Template
+	String() string
+}
+
+type initSystemDetector struct {
+	name    string
+	detect  func() bool
+	factory func() InitSystem
+}
+
+var initSystemDetectors []initSystemDetector
+
+// RegisterInitSystem adds a detectable Linux init system backend. detect is
+// called during flavor resolution and should report whether its init system
+// is present on the running host; factory builds the InitSystem once detect
+// returns true. Detectors are tried in registration order, and can also be
+// selected directly by name via the SERVICE_INIT_SYSTEM environment
+// variable, which is useful in tests.
+func RegisterInitSystem(name string, detect func() bool, factory func() InitSystem) {
+	initSystemDetectors = append(initSystemDetectors, initSystemDetector{name, detect, factory})
+}
+
+func init() {
+	RegisterInitSystem("systemd", isSystemd, func() InitSystem { return systemdSystem{} })
+	RegisterInitSystem("upstart", isUpstart, func() InitSystem { return upstartSystem{} })
+	RegisterInitSystem("openrc", isOpenRC, func() InitSystem { return openrcSystem{} })
+	RegisterInitSystem("runit", isRunit, func() InitSystem { return runitSystem{} })
+	RegisterInitSystem("procd", isProcd, func() InitSystem { return procdSystem{} })
+	RegisterInitSystem("sysvinit", func() bool { return true }, func() InitSystem { return sysVSystem{} })
+}
 
-func getFlavor() initFlavor {
-	flavor := initSystemV
-	if isSystemd() {
-		flavor = initSystemd
-	} else if isUpstart() {
-		flavor = initUpstart
+// getFlavor resolves the Linux init system to target, honoring
+// SERVICE_INIT_SYSTEM as an override before falling back to detection.
+func getFlavor() InitSystem {
+	if name := os.Getenv("SERVICE_INIT_SYSTEM"); name != "" {
+		for _, d := range initSystemDetectors {
+			if d.name == name {
+				return d.factory()
+			}
+		}
 	}
-	return flavor
+	for _, d := range initSystemDetectors {
+		if d.detect() {
+			return d.factory()
+		}
+	}
+	return sysVSystem{}
 }
 
 func isUpstart() bool {
@@ -41,6 +93,25 @@ func isSystemd() bool {
 	return false
 }
 
+func isOpenRC() bool {
+	for _, p := range []string{"/run/openrc", "/etc/init.d/openrc"} {
+		if _, err := os.Stat(p); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func isRunit() bool {
+	_, err := os.Stat("/etc/runit/1")
+	return err == nil
+}
+
+func isProcd() bool {
+	_, err := os.Stat("/sbin/procd")
+	return err == nil
+}
+
 type linuxService struct {
 	i Interface
 	*Config
@@ -48,12 +119,24 @@ type linuxService struct {
 	interactive bool
 }
 
-var flavor = getFlavor()
+var (
+	flavorOnce sync.Once
+	flavor     InitSystem
+)
+
+// currentFlavor resolves and caches the Linux init system to target. It
+// must not run at package-init time: getFlavor depends on the
+// initSystemDetectors registered by this package's own init(), and
+// package-level var initializers run before init() functions.
+func currentFlavor() InitSystem {
+	flavorOnce.Do(func() { flavor = getFlavor() })
+	return flavor
+}
 
 type linuxSystem struct{}
 
 func (ls linuxSystem) String() string {
-	return fmt.Sprintf("Linux %s", flavor.String())
+	return fmt.Sprintf("Linux %s", currentFlavor().String())
 }
 
 var system = linuxSystem{}
@@ -64,7 +147,7 @@ func newService(i Interface, c *Config) (Service, error) {
 		Config: c,
 	}
 	var err error
-	s.interactive, err = isInteractive()
+	s.interactive, err = isInteractive(c.UserService)
 
 	return s, err
 }
@@ -76,49 +159,61 @@ func (s *linuxService) String() string {
 	return s.Name
 }
 
-type initFlavor uint8
-
-func (f initFlavor) String() string {
-	switch f {
-	case initSystemV:
-		return "System-V"
-	case initUpstart:
-		return "Upstart"
-	case initSystemd:
-		return "systemd"
-	default:
-		return "unknown"
-	}
+// linuxTemplateData is the set of fields made available to the init script
+// templates. Fields left at their zero value are omitted by the templates
+// that support omitting them.
+type linuxTemplateData struct {
+	Display     string
+	Description string
+	Path        string
+
+	LimitNOFILE       int
+	Restart           string
+	RestartSec        int
+	SuccessExitStatus string
+	User              string
+	Group             string
+	WorkingDirectory  string
+	Environment       []string
+	ReloadSignal      string
+	PIDFile           string
 }
 
-func (f initFlavor) ConfigPath(name string) string {
-	switch f {
-	case initSystemd:
-		return "/etc/systemd/system/" + name + ".service"
-	case initSystemV:
-		return "/etc/init.d/" + name
-	case initUpstart:
-		return "/etc/init/" + name + ".conf"
-	default:
-		return ""
+// templateData gathers the Config.Option overrides into the data passed to
+// the init script templates.
+func (s *linuxService) templateData(path string) *linuxTemplateData {
+	opts := s.Option
+	var env []string
+	if v, ok := opts["Environment"]; ok {
+		env, _ = v.([]string)
 	}
-}
-
-func (f initFlavor) GetTemplate() *template.Template {
-	var templ string
-	switch f {
-	case initSystemd:
-		templ = systemdScript
-	case initSystemV:
-		templ = systemVScript
-	case initUpstart:
-		templ = upstartScript
+	return &linuxTemplateData{
+		Display:     s.DisplayName,
+		Description: s.Description,
+		Path:        path,
+
+		LimitNOFILE:       opts.int("LimitNOFILE", 0),
+		Restart:           opts.string("Restart", ""),
+		RestartSec:        opts.int("RestartSec", 0),
+		SuccessExitStatus: opts.string("SuccessExitStatus", ""),
+		User:              opts.string("User", s.UserName),
+		Group:             opts.string("Group", ""),
+		WorkingDirectory:  opts.string("WorkingDirectory", s.WorkingDirectory),
+		Environment:       env,
+		ReloadSignal:      opts.string("ReloadSignal", ""),
+		PIDFile:           opts.string("PIDFile", ""),
 	}
-	return template.Must(template.New(f.String() + "Script").Parse(templ))
 }
 
-func isInteractive() (bool, error) {
-	// TODO: Is this true for user services?
+func isInteractive(userService bool) (bool, error) {
+	if userService {
+		// A user service's parent is the user's own systemd instance, not
+		// PID 1, so the PPID check below doesn't apply. systemd sets
+		// INVOCATION_ID (and JOURNAL_STREAM) in the unit's environment for
+		// both system and user units, so its absence means we were started
+		// interactively instead.
+		return os.Getenv("INVOCATION_ID") == "" && os.Getenv("JOURNAL_STREAM") == "", nil
+	}
 	return os.Getppid() != 1, nil
 }
 
@@ -127,116 +222,213 @@ func (s *linuxService) Interactive() bool {
 }
 
 func (s *linuxService) Install() error {
-	confPath := flavor.ConfigPath(s.Name)
-	_, err := os.Stat(confPath)
-	if err == nil {
-		return fmt.Errorf("Init already exists: %s", confPath)
+	return currentFlavor().Install(s)
+}
+
+func (s *linuxService) Remove() error {
+	return currentFlavor().Remove(s)
+}
+
+// writeTemplate renders the given InitSystem's template to its ConfigPath,
+// failing if a config already exists there. It is shared by the built-in
+// backends whose Install is "write one templated unit file".
+func writeTemplate(f InitSystem, s *linuxService) (confPath string, err error) {
+	confPath = f.ConfigPath(s)
+	if _, err = os.Stat(confPath); err == nil {
+		return confPath, fmt.Errorf("Init already exists: %s", confPath)
+	}
+
+	if err = os.MkdirAll(filepath.Dir(confPath), 0755); err != nil {
+		return confPath, err
 	}
 
-	f, err := os.Create(confPath)
+	file, err := os.Create(confPath)
 	if err != nil {
-		return err
+		return confPath, err
 	}
-	defer f.Close()
+	defer file.Close()
 
 	path, err := osext.Executable()
 	if err != nil {
-		return err
+		return confPath, err
 	}
 
-	var to = &struct {
-		Display     string
-		Description string
-		Path        string
-	}{
-		s.DisplayName,
-		s.Description,
-		path,
+	return confPath, f.Template().Execute(file, s.templateData(path))
+}
+
+func (s *linuxService) Logger() (Logger, error) {
+	if s.interactive {
+		return ConsoleLogger, nil
 	}
+	return s.SystemLogger()
+}
+func (s *linuxService) SystemLogger() (Logger, error) {
+	return newSysLogger(s.Name)
+}
 
-	err = flavor.GetTemplate().Execute(f, to)
+// sysLogger writes to the local syslog daemon via /dev/log.
+type sysLogger struct {
+	*syslog.Writer
+}
+
+// newSysLogger opens a connection to the local syslog daemon, tagged with
+// name.
+func newSysLogger(name string) (Logger, error) {
+	w, err := syslog.New(syslog.LOG_INFO, name)
+	if err != nil {
+		return nil, err
+	}
+	return sysLogger{w}, nil
+}
+
+func (l sysLogger) Error(v ...interface{}) error {
+	return l.Writer.Err(fmt.Sprint(v...))
+}
+func (l sysLogger) Warning(v ...interface{}) error {
+	return l.Writer.Warning(fmt.Sprint(v...))
+}
+func (l sysLogger) Info(v ...interface{}) error {
+	return l.Writer.Info(fmt.Sprint(v...))
+}
+
+func (l sysLogger) Errorf(format string, a ...interface{}) error {
+	return l.Writer.Err(fmt.Sprintf(format, a...))
+}
+func (l sysLogger) Warningf(format string, a ...interface{}) error {
+	return l.Writer.Warning(fmt.Sprintf(format, a...))
+}
+func (l sysLogger) Infof(format string, a ...interface{}) error {
+	return l.Writer.Info(fmt.Sprintf(format, a...))
+}
+
+func (s *linuxService) Run() (err error) {
+	err = s.i.Start(s)
 	if err != nil {
 		return err
 	}
 
-	if flavor == initSystemV {
-		if err = os.Chmod(confPath, 0755); err != nil {
-			return err
-		}
-		for _, i := range [...]string{"2", "3", "4", "5"} {
-			if err = os.Symlink(confPath, "/etc/rc"+i+".d/S50"+s.Name); err != nil {
-				continue
-			}
-		}
-		for _, i := range [...]string{"0", "1", "6"} {
-			if err = os.Symlink(confPath, "/etc/rc"+i+".d/K02"+s.Name); err != nil {
+	sigChan := make(chan os.Signal, 3)
+	signal.Notify(sigChan, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
+
+	term := make(chan os.Signal, 1)
+	go func() {
+		for sig := range sigChan {
+			if sig == syscall.SIGHUP {
+				if r, ok := s.i.(Reloader); ok {
+					r.Reload(s)
+				}
 				continue
 			}
+			term <- sig
+			return
 		}
+	}()
+
+	var sig os.Signal
+	if s.RunWait != nil {
+		s.RunWait()
+	} else {
+		sig = <-term
 	}
 
-	if flavor == initSystemd {
-		return exec.Command("systemctl", "daemon-reload").Run()
+	if err = s.shutdown(); err != nil {
+		return err
 	}
 
+	s.exitForSignal(sig)
 	return nil
 }
 
-func (s *linuxService) Remove() error {
-	if flavor == initSystemd {
-		exec.Command("systemctl", "disable", s.Name+".service").Run()
+// shutdown gives an Interface implementing Shutdowner up to
+// Config.StopTimeout to run its own graceful-shutdown logic before falling
+// back to Stop.
+func (s *linuxService) shutdown() error {
+	sd, ok := s.i.(Shutdowner)
+	if !ok {
+		return s.i.Stop(s)
 	}
-	if err := os.Remove(flavor.ConfigPath(s.Name)); err != nil {
-		return err
+
+	timeout := s.StopTimeout
+	if timeout <= 0 {
+		timeout = defaultStopTimeout
 	}
-	return nil
-}
 
-func (s *linuxService) Logger() (Logger, error) {
-	if s.interactive {
-		return ConsoleLogger, nil
+	done := make(chan error, 1)
+	go func() { done <- sd.Shutdown(s) }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+		return s.i.Stop(s)
 	}
-	return s.SystemLogger()
-}
-func (s *linuxService) SystemLogger() (Logger, error) {
-	return newSysLogger(s.Name)
 }
 
-func (s *linuxService) Run() (err error) {
-	err = s.i.Start(s)
-	if err != nil {
-		return err
+// exitForSignal terminates the process with exit code 128+signum when that
+// code is listed in Option SuccessExitStatus, i.e. when systemd (or an
+// equivalent init system) is configured to treat it as a clean stop for the
+// given signal. Otherwise, or when sig is not a syscall.Signal, it returns
+// without exiting.
+func (s *linuxService) exitForSignal(sig os.Signal) {
+	signum, ok := sig.(syscall.Signal)
+	if !ok {
+		return
 	}
+	code := 128 + int(signum)
 
-	sigChan := make(chan os.Signal, 3)
+	for _, allowed := range strings.Fields(s.Option.string("SuccessExitStatus", "")) {
+		if allowed == fmt.Sprint(code) {
+			os.Exit(code)
+		}
+	}
+}
 
-	signal.Notify(sigChan, os.Interrupt, os.Kill)
+func (s *linuxService) Start() error {
+	return currentFlavor().Start(s)
+}
 
-	<-sigChan
+func (s *linuxService) Stop() error {
+	return currentFlavor().Stop(s)
+}
 
-	return s.i.Stop(s)
+func (s *linuxService) Status() (StatusInfo, error) {
+	return currentFlavor().Status(s)
 }
 
-func (s *linuxService) Start() error {
-	switch flavor {
-	case initSystemd:
-		return exec.Command("systemctl", "start", s.Name+".service").Run()
-	case initUpstart:
-		return exec.Command("initctl", "start", s.Name).Run()
-	default:
-		return exec.Command("service", s.Name, "start").Run()
+// statusFromExitCode runs cmd and maps its exit status to a Status: zero
+// exit means running, a non-zero exit means stopped (the convention `service
+// status` and our own generated init scripts follow), and any other error
+// (e.g. the command was not found) is reported as StatusUnknown.
+func statusFromExitCode(cmd *exec.Cmd) (Status, error) {
+	err := cmd.Run()
+	if err == nil {
+		return StatusRunning, nil
 	}
+	if _, ok := err.(*exec.ExitError); ok {
+		return StatusStopped, nil
+	}
+	return StatusUnknown, err
 }
 
-func (s *linuxService) Stop() error {
-	switch flavor {
-	case initSystemd:
-		return exec.Command("systemctl", "stop", s.Name+".service").Run()
-	case initUpstart:
-		return exec.Command("initctl", "stop", s.Name).Run()
-	default:
-		return exec.Command("service", s.Name, "stop").Run()
+// pidFile returns the Option["PIDFile"] override if set, otherwise
+// defaultPath — the same fallback the init script templates use.
+func (s *linuxService) pidFile(defaultPath string) string {
+	return s.Option.string("PIDFile", defaultPath)
+}
+
+// pidFromFile reads a pid that a service, or the init script supervising
+// it, recorded at path. It returns 0 if the file is missing or doesn't
+// hold a valid pid.
+func pidFromFile(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0
 	}
+	return pid
 }
 
 func (s *linuxService) Restart() error {
@@ -267,7 +459,7 @@ const systemVScript = `#!/bin/sh
 cmd="{{.Path}}"
 
 name=$(basename $0)
-pid_file="/var/run/$name.pid"
+pid_file="{{if .PIDFile}}{{.PIDFile}}{{else}}/var/run/$name.pid{{end}}"
 stdout_log="/var/log/$name.log"
 stderr_log="/var/log/$name.err"
 
@@ -285,7 +477,7 @@ case "$1" in
             echo "Already started"
         else
             echo "Starting $name"
-            $cmd >> "$stdout_log" 2>> "$stderr_log" &
+            {{if .User}}su -s /bin/sh -c '"$0" >> "$1" 2>> "$2"' {{.User}} "$cmd" "$stdout_log" "$stderr_log" &{{else}}$cmd >> "$stdout_log" 2>> "$stderr_log" &{{end}}
             echo $! > "$pid_file"
             if ! is_running; then
                 echo "Unable to start, see $stdout_log and $stderr_log"
@@ -349,14 +541,18 @@ description     "{{.Display}}"
 start on filesystem or runlevel [2345]
 stop on runlevel [!2345]
 
-#setuid username
+{{if .User}}setuid {{.User}}{{end}}
+{{if .Group}}setgid {{.Group}}{{end}}
 
 respawn
-respawn limit 10 5
+respawn limit {{if .RestartSec}}10 {{.RestartSec}}{{else}}10 5{{end}}
 umask 022
 
 console none
 
+{{if .WorkingDirectory}}chdir {{.WorkingDirectory}}{{end}}
+{{range .Environment}}env {{.}}
+{{end}}
 pre-start script
     test -x {{.Path}} || { stop; exit 0; }
 end script
@@ -373,7 +569,422 @@ ConditionFileIsExecutable={{.Path}}
 StartLimitInterval=5
 StartLimitBurst=10
 ExecStart={{.Path}}
-
+{{if .WorkingDirectory}}WorkingDirectory={{.WorkingDirectory}}
+{{end}}{{if .User}}User={{.User}}
+{{end}}{{if .Group}}Group={{.Group}}
+{{end}}{{if .LimitNOFILE}}LimitNOFILE={{.LimitNOFILE}}
+{{end}}{{if .Restart}}Restart={{.Restart}}
+{{end}}{{if .RestartSec}}RestartSec={{.RestartSec}}
+{{end}}{{if .SuccessExitStatus}}SuccessExitStatus={{.SuccessExitStatus}}
+{{end}}{{if .ReloadSignal}}ReloadSignal={{.ReloadSignal}}
+{{end}}{{if .PIDFile}}PIDFile={{.PIDFile}}
+{{end}}{{range .Environment}}Environment={{.}}
+{{end}}
 [Install]
 WantedBy=multi-user.target
 `
+
+type systemdSystem struct{}
+
+func (systemdSystem) String() string { return "systemd" }
+
+func (systemdSystem) ConfigPath(s *linuxService) string {
+	if s.UserService {
+		return userSystemdDir(s) + "/" + s.Name + ".service"
+	}
+	return "/etc/systemd/system/" + s.Name + ".service"
+}
+
+// userSystemdDir resolves the systemd user unit directory to install into,
+// honoring $XDG_CONFIG_HOME and Config.Username so root can install a user
+// service on another account's behalf.
+func userSystemdDir(s *linuxService) string {
+	if s.Username == "" {
+		if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+			return dir + "/systemd/user"
+		}
+	}
+	return userHomeDir(s.Username) + "/.config/systemd/user"
+}
+
+func userHomeDir(username string) string {
+	if username == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			return home
+		}
+		return os.Getenv("HOME")
+	}
+	if u, err := user.Lookup(username); err == nil {
+		return u.HomeDir
+	}
+	return "/home/" + username
+}
+
+// systemctlArgs prefixes verb/args with --user when the service targets the
+// caller's own systemd instance rather than the system-wide one.
+func systemctlArgs(s *linuxService, verb string, args ...string) []string {
+	out := []string{}
+	if s.UserService {
+		out = append(out, "--user")
+	}
+	out = append(out, verb)
+	return append(out, args...)
+}
+
+func (systemdSystem) Template() *template.Template {
+	return template.Must(template.New("systemdScript").Parse(systemdScript))
+}
+
+func (f systemdSystem) Install(s *linuxService) error {
+	if _, err := writeTemplate(f, s); err != nil {
+		return err
+	}
+	return exec.Command("systemctl", systemctlArgs(s, "daemon-reload")...).Run()
+}
+
+func (f systemdSystem) Remove(s *linuxService) error {
+	exec.Command("systemctl", systemctlArgs(s, "disable", s.Name+".service")...).Run()
+	return os.Remove(f.ConfigPath(s))
+}
+
+func (systemdSystem) Start(s *linuxService) error {
+	return exec.Command("systemctl", systemctlArgs(s, "start", s.Name+".service")...).Run()
+}
+
+func (systemdSystem) Stop(s *linuxService) error {
+	return exec.Command("systemctl", systemctlArgs(s, "stop", s.Name+".service")...).Run()
+}
+
+func (systemdSystem) Status(s *linuxService) (StatusInfo, error) {
+	out, err := exec.Command("systemctl", systemctlArgs(s, "is-active", s.Name+".service")...).CombinedOutput()
+	switch strings.TrimSpace(string(out)) {
+	case "active":
+		pid, _ := systemdMainPID(s)
+		return StatusInfo{Status: StatusRunning, PID: pid}, nil
+	case "inactive", "failed":
+		return StatusInfo{Status: StatusStopped}, nil
+	}
+	if err != nil {
+		return StatusInfo{Status: StatusUnknown}, err
+	}
+	return StatusInfo{Status: StatusUnknown}, nil
+}
+
+// systemdMainPID looks up the pid systemd is tracking as the unit's main
+// process.
+func systemdMainPID(s *linuxService) (int, error) {
+	out, err := exec.Command("systemctl", systemctlArgs(s, "show", "-p", "MainPID", "--value", s.Name+".service")...).Output()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(out)))
+}
+
+type upstartSystem struct{}
+
+func (upstartSystem) String() string { return "Upstart" }
+
+func (upstartSystem) ConfigPath(s *linuxService) string {
+	return "/etc/init/" + s.Name + ".conf"
+}
+
+func (upstartSystem) Template() *template.Template {
+	return template.Must(template.New("upstartScript").Parse(upstartScript))
+}
+
+func (f upstartSystem) Install(s *linuxService) error {
+	if s.UserService {
+		return ErrNoUserServiceSupport
+	}
+	_, err := writeTemplate(f, s)
+	return err
+}
+
+func (f upstartSystem) Remove(s *linuxService) error {
+	return os.Remove(f.ConfigPath(s))
+}
+
+func (upstartSystem) Start(s *linuxService) error {
+	return exec.Command("initctl", "start", s.Name).Run()
+}
+
+func (upstartSystem) Stop(s *linuxService) error {
+	return exec.Command("initctl", "stop", s.Name).Run()
+}
+
+// upstartPIDRE matches the "process NNNN" fragment `initctl status` prints
+// for a running job.
+var upstartPIDRE = regexp.MustCompile(`process (\d+)`)
+
+func (upstartSystem) Status(s *linuxService) (StatusInfo, error) {
+	out, err := exec.Command("initctl", "status", s.Name).CombinedOutput()
+	if err != nil {
+		return StatusInfo{Status: StatusUnknown}, nil
+	}
+	text := string(out)
+	if !strings.Contains(text, s.Name+" start/running") {
+		return StatusInfo{Status: StatusStopped}, nil
+	}
+	info := StatusInfo{Status: StatusRunning}
+	if m := upstartPIDRE.FindStringSubmatch(text); m != nil {
+		info.PID, _ = strconv.Atoi(m[1])
+	}
+	return info, nil
+}
+
+type sysVSystem struct{}
+
+func (sysVSystem) String() string { return "System-V" }
+
+func (sysVSystem) ConfigPath(s *linuxService) string {
+	return "/etc/init.d/" + s.Name
+}
+
+func (sysVSystem) Template() *template.Template {
+	return template.Must(template.New("systemVScript").Parse(systemVScript))
+}
+
+func (f sysVSystem) Install(s *linuxService) error {
+	if s.UserService {
+		return ErrNoUserServiceSupport
+	}
+	confPath, err := writeTemplate(f, s)
+	if err != nil {
+		return err
+	}
+
+	if err = os.Chmod(confPath, 0755); err != nil {
+		return err
+	}
+	for _, i := range [...]string{"2", "3", "4", "5"} {
+		if err = os.Symlink(confPath, "/etc/rc"+i+".d/S50"+s.Name); err != nil {
+			continue
+		}
+	}
+	for _, i := range [...]string{"0", "1", "6"} {
+		if err = os.Symlink(confPath, "/etc/rc"+i+".d/K02"+s.Name); err != nil {
+			continue
+		}
+	}
+	return nil
+}
+
+func (f sysVSystem) Remove(s *linuxService) error {
+	return os.Remove(f.ConfigPath(s))
+}
+
+func (sysVSystem) Start(s *linuxService) error {
+	return exec.Command("service", s.Name, "start").Run()
+}
+
+func (sysVSystem) Stop(s *linuxService) error {
+	return exec.Command("service", s.Name, "stop").Run()
+}
+
+func (sysVSystem) Status(s *linuxService) (StatusInfo, error) {
+	status, err := statusFromExitCode(exec.Command("service", s.Name, "status"))
+	info := StatusInfo{Status: status}
+	if status == StatusRunning {
+		info.PID = pidFromFile(s.pidFile("/var/run/" + s.Name + ".pid"))
+	}
+	return info, err
+}
+
+// openrcScript follows the OpenRC (Alpine, Gentoo) init script convention:
+// a depend() function, with openrc-run's default start/stop (driven by
+// start-stop-daemon) configured through command, command_background and
+// pidfile.
+const openrcScript = `#!/sbin/openrc-run
+description="{{.Description}}"
+command="{{.Path}}"
+command_background=true
+pidfile="{{if .PIDFile}}{{.PIDFile}}{{else}}/run/$RC_SVCNAME.pid{{end}}"
+{{if .User}}command_user="{{.User}}{{if .Group}}:{{.Group}}{{end}}"
+{{end}}{{if .WorkingDirectory}}directory="{{.WorkingDirectory}}"
+{{end}}
+depend() {
+	need net
+}
+`
+
+type openrcSystem struct{}
+
+func (openrcSystem) String() string { return "OpenRC" }
+
+func (openrcSystem) ConfigPath(s *linuxService) string {
+	return "/etc/init.d/" + s.Name
+}
+
+func (openrcSystem) Template() *template.Template {
+	return template.Must(template.New("openrcScript").Parse(openrcScript))
+}
+
+func (f openrcSystem) Install(s *linuxService) error {
+	if s.UserService {
+		return ErrNoUserServiceSupport
+	}
+	confPath, err := writeTemplate(f, s)
+	if err != nil {
+		return err
+	}
+	if err = os.Chmod(confPath, 0755); err != nil {
+		return err
+	}
+	return exec.Command("rc-update", "add", s.Name, "default").Run()
+}
+
+func (f openrcSystem) Remove(s *linuxService) error {
+	exec.Command("rc-update", "del", s.Name, "default").Run()
+	return os.Remove(f.ConfigPath(s))
+}
+
+func (openrcSystem) Start(s *linuxService) error {
+	return exec.Command("rc-service", s.Name, "start").Run()
+}
+
+func (openrcSystem) Stop(s *linuxService) error {
+	return exec.Command("rc-service", s.Name, "stop").Run()
+}
+
+func (openrcSystem) Status(s *linuxService) (StatusInfo, error) {
+	status, err := statusFromExitCode(exec.Command("rc-service", s.Name, "status"))
+	info := StatusInfo{Status: status}
+	if status == StatusRunning {
+		info.PID = pidFromFile(s.pidFile("/run/" + s.Name + ".pid"))
+	}
+	return info, err
+}
+
+// runitScript is runit's supervisor-style "run" script: it execs the
+// service directly so it stays under runsv's supervision.
+const runitScript = `#!/bin/sh
+# {{.Description}}
+{{if .WorkingDirectory}}cd {{.WorkingDirectory}}
+{{end}}{{range .Environment}}export {{.}}
+{{end}}{{if .User}}exec chpst -u {{.User}}{{if .Group}}:{{.Group}}{{end}} {{.Path}}
+{{else}}exec {{.Path}}
+{{end}}`
+
+type runitSystem struct{}
+
+func (runitSystem) String() string { return "runit" }
+
+func (runitSystem) ConfigPath(s *linuxService) string {
+	return "/etc/sv/" + s.Name + "/run"
+}
+
+func (runitSystem) Template() *template.Template {
+	return template.Must(template.New("runitScript").Parse(runitScript))
+}
+
+func (f runitSystem) Install(s *linuxService) error {
+	if s.UserService {
+		return ErrNoUserServiceSupport
+	}
+	svDir := "/etc/sv/" + s.Name
+	if err := os.MkdirAll(svDir, 0755); err != nil {
+		return err
+	}
+	confPath, err := writeTemplate(f, s)
+	if err != nil {
+		return err
+	}
+	if err = os.Chmod(confPath, 0755); err != nil {
+		return err
+	}
+	return os.Symlink(svDir, "/etc/service/"+s.Name)
+}
+
+func (runitSystem) Remove(s *linuxService) error {
+	os.Remove("/etc/service/" + s.Name)
+	return os.RemoveAll("/etc/sv/" + s.Name)
+}
+
+func (runitSystem) Start(s *linuxService) error {
+	return exec.Command("sv", "start", s.Name).Run()
+}
+
+func (runitSystem) Stop(s *linuxService) error {
+	return exec.Command("sv", "stop", s.Name).Run()
+}
+
+// runitPIDRE matches the "(pid NNNN)" fragment `sv status` prints for a
+// running service.
+var runitPIDRE = regexp.MustCompile(`\(pid (\d+)\)`)
+
+func (runitSystem) Status(s *linuxService) (StatusInfo, error) {
+	out, err := exec.Command("sv", "status", s.Name).CombinedOutput()
+	text := string(out)
+	if strings.HasPrefix(text, "run:") {
+		info := StatusInfo{Status: StatusRunning}
+		if m := runitPIDRE.FindStringSubmatch(text); m != nil {
+			info.PID, _ = strconv.Atoi(m[1])
+		}
+		return info, nil
+	}
+	if err != nil {
+		return StatusInfo{Status: StatusUnknown}, nil
+	}
+	return StatusInfo{Status: StatusStopped}, nil
+}
+
+// procdScript targets OpenWRT's procd, which supervises services described
+// via UCI-style start_service()/service_triggers() hooks.
+const procdScript = `#!/bin/sh /etc/rc.common
+START=95
+STOP=05
+USE_PROCD=1
+
+start_service() {
+	procd_open_instance
+	procd_set_param command {{.Path}}
+	{{if .User}}procd_set_param user {{.User}}
+	{{end}}{{if .WorkingDirectory}}procd_set_param cwd {{.WorkingDirectory}}
+	{{end}}procd_set_param respawn
+	procd_close_instance
+}
+`
+
+type procdSystem struct{}
+
+func (procdSystem) String() string { return "procd" }
+
+func (procdSystem) ConfigPath(s *linuxService) string {
+	return "/etc/init.d/" + s.Name
+}
+
+func (procdSystem) Template() *template.Template {
+	return template.Must(template.New("procdScript").Parse(procdScript))
+}
+
+func (f procdSystem) Install(s *linuxService) error {
+	if s.UserService {
+		return ErrNoUserServiceSupport
+	}
+	confPath, err := writeTemplate(f, s)
+	if err != nil {
+		return err
+	}
+	if err = os.Chmod(confPath, 0755); err != nil {
+		return err
+	}
+	return exec.Command(confPath, "enable").Run()
+}
+
+func (f procdSystem) Remove(s *linuxService) error {
+	exec.Command(f.ConfigPath(s), "disable").Run()
+	return os.Remove(f.ConfigPath(s))
+}
+
+func (procdSystem) Start(s *linuxService) error {
+	return exec.Command("service", s.Name, "start").Run()
+}
+
+func (procdSystem) Stop(s *linuxService) error {
+	return exec.Command("service", s.Name, "stop").Run()
+}
+
+func (procdSystem) Status(s *linuxService) (StatusInfo, error) {
+	status, err := statusFromExitCode(exec.Command("service", s.Name, "status"))
+	return StatusInfo{Status: status}, err
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestGetFlavorEnvOverride(t *testing.T) {
+	saved, hadSaved := os.LookupEnv("SERVICE_INIT_SYSTEM")
+	defer func() {
+		if hadSaved {
+			os.Setenv("SERVICE_INIT_SYSTEM", saved)
+		} else {
+			os.Unsetenv("SERVICE_INIT_SYSTEM")
+		}
+	}()
+
+	os.Setenv("SERVICE_INIT_SYSTEM", "runit")
+	got := getFlavor()
+	if _, ok := got.(runitSystem); !ok {
+		t.Fatalf("getFlavor() with SERVICE_INIT_SYSTEM=runit = %T, want runitSystem", got)
+	}
+}
+
+func TestGetFlavorDetection(t *testing.T) {
+	saved := initSystemDetectors
+	defer func() { initSystemDetectors = saved }()
+
+	os.Unsetenv("SERVICE_INIT_SYSTEM")
+	initSystemDetectors = nil
+	RegisterInitSystem("fake-no-match", func() bool { return false }, func() InitSystem { return systemdSystem{} })
+	RegisterInitSystem("fake-match", func() bool { return true }, func() InitSystem { return openrcSystem{} })
+
+	got := getFlavor()
+	if _, ok := got.(openrcSystem); !ok {
+		t.Fatalf("getFlavor() = %T, want openrcSystem from the matching detector", got)
+	}
+}
+
+func TestGetFlavorFallsBackToSysV(t *testing.T) {
+	saved := initSystemDetectors
+	defer func() { initSystemDetectors = saved }()
+
+	os.Unsetenv("SERVICE_INIT_SYSTEM")
+	initSystemDetectors = nil
+
+	got := getFlavor()
+	if _, ok := got.(sysVSystem); !ok {
+		t.Fatalf("getFlavor() with no detectors = %T, want sysVSystem", got)
+	}
+}
+
+// TestInitSystemDetectorsRegisteredBeforeUse guards against the
+// package-init-order bug fixed in currentFlavor: initSystemDetectors must
+// already be populated by the time any flavor resolution runs.
+func TestInitSystemDetectorsRegisteredBeforeUse(t *testing.T) {
+	if len(initSystemDetectors) == 0 {
+		t.Fatal("initSystemDetectors is empty; RegisterInitSystem's init() should run before flavor resolution")
+	}
+}
+
+func templateFixture() *linuxService {
+	return &linuxService{
+		Config: &Config{
+			Name:        "testsvc",
+			DisplayName: "Test Service",
+			Description: "a service for tests",
+			UserName:    "svcuser",
+			Option:      KeyValue{"PIDFile": "/run/testsvc.pid"},
+		},
+	}
+}
+
+func TestTemplatesRenderWithoutError(t *testing.T) {
+	s := templateFixture()
+	systems := []InitSystem{
+		systemdSystem{}, upstartSystem{}, sysVSystem{}, openrcSystem{}, runitSystem{}, procdSystem{},
+	}
+	for _, sys := range systems {
+		var buf bytes.Buffer
+		if err := sys.Template().Execute(&buf, s.templateData("/usr/bin/testsvc")); err != nil {
+			t.Errorf("%s template: %v", sys, err)
+			continue
+		}
+		if buf.Len() == 0 {
+			t.Errorf("%s template rendered empty output", sys)
+		}
+	}
+}
+
+// TestSysVScriptBackgroundsSuItself is a regression test for the SysV
+// init script's User branch: su must be backgrounded, not the command it
+// runs inside its subshell, or $! captures nothing and is_running never
+// sees the daemon.
+func TestSysVScriptBackgroundsSuItself(t *testing.T) {
+	s := templateFixture()
+	s.Option = KeyValue{"User": "svcuser"}
+
+	var buf bytes.Buffer
+	if err := (sysVSystem{}).Template().Execute(&buf, s.templateData("/usr/bin/testsvc")); err != nil {
+		t.Fatalf("template: %v", err)
+	}
+
+	var startLine string
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.Contains(line, "su -s") {
+			startLine = line
+			break
+		}
+	}
+	if startLine == "" {
+		t.Fatal("expected a su invocation in the rendered start block")
+	}
+	if !strings.HasSuffix(strings.TrimSpace(startLine), "&") {
+		t.Fatalf("su invocation is not backgrounded: %q", startLine)
+	}
+	if strings.Contains(startLine, `&' `) {
+		t.Fatalf("daemon is backgrounded inside the su subshell instead of su itself: %q", startLine)
+	}
+}
+
+func TestStatusFromExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  *exec.Cmd
+		want Status
+	}{
+		{"zero exit", exec.Command("true"), StatusRunning},
+		{"nonzero exit", exec.Command("false"), StatusStopped},
+		{"command not found", exec.Command("/no/such/binary-xyz"), StatusUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := statusFromExitCode(tt.cmd)
+			if got != tt.want {
+				t.Errorf("statusFromExitCode() = %v, want %v (err=%v)", got, tt.want, err)
+			}
+			if tt.name == "command not found" && err == nil {
+				t.Error("expected a non-nil error for a missing command")
+			}
+		})
+	}
+}